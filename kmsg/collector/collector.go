@@ -0,0 +1,136 @@
+// Package collector adapts a kmsg.Parser into a prometheus.Collector,
+// turning the stream of kernel messages into a handful of counters and
+// gauges suitable for scraping.
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/zhu733756/dmesg_exporter/kmsg"
+)
+
+const namespace = "dmesg"
+
+// Matcher decides whether a Message should be counted. Messages that
+// don't match are dropped before they can create new label series.
+type Matcher func(*kmsg.Message) bool
+
+// Option configures a Collector returned by New.
+type Option func(*Collector)
+
+// WithMatcher installs a Matcher that filters records before they
+// reach the collector's metrics, bounding label cardinality when
+// optional metadata is folded into a label.
+func WithMatcher(matcher Matcher) Option {
+	return func(c *Collector) {
+		c.matcher = matcher
+	}
+}
+
+// Collector implements prometheus.Collector by subscribing to a
+// kmsg.Parser and turning the Messages it streams into metrics.
+type Collector struct {
+	parser  kmsg.Parser
+	matcher Matcher
+
+	messagesTotal      *prometheus.CounterVec
+	lastMessageSeconds *prometheus.GaugeVec
+	messageBytesTotal  *prometheus.CounterVec
+	parseErrorsTotal   prometheus.Counter
+}
+
+// New returns a Collector that reads Messages from parser once Run is
+// called. It also installs itself as parser's Logger, so malformed
+// records and non-fatal read errors are counted in
+// dmesg_parse_errors_total.
+func New(parser kmsg.Parser, opts ...Option) *Collector {
+	c := &Collector{
+		parser: parser,
+		messagesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_total",
+			Help:      "Total number of kmsg messages seen, by facility and priority.",
+		}, []string{"facility", "priority"}),
+		lastMessageSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_message_timestamp_seconds",
+			Help:      "Timestamp of the last kmsg message seen, by facility and priority.",
+		}, []string{"facility", "priority"}),
+		messageBytesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "message_bytes_total",
+			Help:      "Total number of bytes seen in kmsg message text, by facility.",
+		}, []string{"facility"}),
+		parseErrorsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parse_errors_total",
+			Help:      "Total number of malformed kmsg records and non-fatal read errors reported by the parser.",
+		}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.parser.SetLogger(parseErrorLogger{c.parseErrorsTotal})
+
+	return c
+}
+
+// parseErrorLogger adapts a prometheus.Counter into a kmsg.Logger,
+// counting every malformed record or non-fatal read error the parser
+// reports instead of formatting them anywhere.
+type parseErrorLogger struct {
+	counter prometheus.Counter
+}
+
+func (l parseErrorLogger) Printf(format string, v ...interface{}) {
+	l.counter.Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.messagesTotal.Describe(ch)
+	c.lastMessageSeconds.Describe(ch)
+	c.messageBytesTotal.Describe(ch)
+	c.parseErrorsTotal.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.messagesTotal.Collect(ch)
+	c.lastMessageSeconds.Collect(ch)
+	c.messageBytesTotal.Collect(ch)
+	c.parseErrorsTotal.Collect(ch)
+}
+
+// Run consumes Messages from the parser and updates metrics until ctx
+// is cancelled or the parser's channel is closed, whichever comes
+// first.
+func (c *Collector) Run(ctx context.Context) error {
+	messages := c.parser.Parse()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			if c.matcher != nil && !c.matcher(&m) {
+				continue
+			}
+
+			facility := m.Facility.String()
+			priority := m.Priority.String()
+
+			c.messagesTotal.WithLabelValues(facility, priority).Inc()
+			c.lastMessageSeconds.WithLabelValues(facility, priority).Set(float64(m.Timestamp.Unix()))
+			c.messageBytesTotal.WithLabelValues(facility).Add(float64(len(m.Message)))
+		}
+	}
+}
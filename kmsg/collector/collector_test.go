@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/zhu733756/dmesg_exporter/kmsg"
+)
+
+// fakeParser is an in-memory kmsg.Parser that replays canned Messages,
+// used to drive the Collector under test without touching /dev/kmsg.
+type fakeParser struct {
+	messages chan kmsg.Message
+	logger   kmsg.Logger
+}
+
+func newFakeParser(messages ...kmsg.Message) *fakeParser {
+	ch := make(chan kmsg.Message, len(messages))
+	for _, m := range messages {
+		ch <- m
+	}
+	close(ch)
+
+	return &fakeParser{messages: ch}
+}
+
+func (p *fakeParser) SeekEnd() error               { return nil }
+func (p *fakeParser) Parse() <-chan kmsg.Message   { return p.messages }
+func (p *fakeParser) SetLogger(logger kmsg.Logger) { p.logger = logger }
+func (p *fakeParser) Close() error                 { return nil }
+
+func TestCollectorCountsMessagesByFacilityAndPriority(t *testing.T) {
+	parser := newFakeParser(
+		kmsg.Message{Facility: kmsg.FacilityKern, Priority: kmsg.PriorityErr, Message: "oops", Timestamp: time.Unix(100, 0)},
+		kmsg.Message{Facility: kmsg.FacilityKern, Priority: kmsg.PriorityErr, Message: "again", Timestamp: time.Unix(200, 0)},
+		kmsg.Message{Facility: kmsg.FacilityUser, Priority: kmsg.PriorityInfo, Message: "hi", Timestamp: time.Unix(150, 0)},
+	)
+
+	c := New(parser)
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	counter, err := c.messagesTotal.GetMetricWithLabelValues("kern", "err")
+	if err != nil {
+		t.Fatalf("couldn't get counter: %v", err)
+	}
+
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		t.Fatalf("couldn't write metric: %v", err)
+	}
+
+	if got := metric.GetCounter().GetValue(); got != 2 {
+		t.Errorf("dmesg_messages_total{facility=kern,priority=err} = %v, want 2", got)
+	}
+
+	gauge, err := c.lastMessageSeconds.GetMetricWithLabelValues("kern", "err")
+	if err != nil {
+		t.Fatalf("couldn't get gauge: %v", err)
+	}
+
+	metric = &dto.Metric{}
+	if err := gauge.Write(metric); err != nil {
+		t.Fatalf("couldn't write metric: %v", err)
+	}
+
+	if got := metric.GetGauge().GetValue(); got != 200 {
+		t.Errorf("dmesg_last_message_timestamp_seconds{facility=kern,priority=err} = %v, want 200", got)
+	}
+}
+
+func TestCollectorWithMatcherDropsUnmatchedMessages(t *testing.T) {
+	parser := newFakeParser(
+		kmsg.Message{Facility: kmsg.FacilityKern, Priority: kmsg.PriorityErr, Message: "oops"},
+		kmsg.Message{Facility: kmsg.FacilityUser, Priority: kmsg.PriorityInfo, Message: "hi"},
+	)
+
+	c := New(parser, WithMatcher(func(m *kmsg.Message) bool {
+		return m.Facility == kmsg.FacilityKern
+	}))
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	userCounter, err := c.messageBytesTotal.GetMetricWithLabelValues("user")
+	if err != nil {
+		t.Fatalf("couldn't get counter: %v", err)
+	}
+
+	userMetric := &dto.Metric{}
+	if err := userCounter.Write(userMetric); err != nil {
+		t.Fatalf("couldn't write metric: %v", err)
+	}
+
+	if got := userMetric.GetCounter().GetValue(); got != 0 {
+		t.Errorf("dmesg_message_bytes_total{facility=user} = %v, want 0 (filtered out by the matcher)", got)
+	}
+
+	counter, err := c.messageBytesTotal.GetMetricWithLabelValues("kern")
+	if err != nil {
+		t.Fatalf("couldn't get counter: %v", err)
+	}
+
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		t.Fatalf("couldn't write metric: %v", err)
+	}
+
+	if got := metric.GetCounter().GetValue(); got != 4 {
+		t.Errorf("dmesg_message_bytes_total{facility=kern} = %v, want 4", got)
+	}
+}
+
+func TestCollectorParseErrorsIncrementParseErrorsTotal(t *testing.T) {
+	parser := newFakeParser()
+	c := New(parser)
+
+	parser.logger.Printf("malformed record: %v", "boom")
+
+	metric := &dto.Metric{}
+	if err := c.parseErrorsTotal.Write(metric); err != nil {
+		t.Fatalf("couldn't write metric: %v", err)
+	}
+
+	if got := metric.GetCounter().GetValue(); got != 1 {
+		t.Errorf("dmesg_parse_errors_total = %v, want 1", got)
+	}
+}
@@ -3,6 +3,7 @@
 package kmsg
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,27 @@ const (
 	PriorityDebug
 )
 
+// priorityNames holds the canonical syslog severity name for each
+// Priority, indexed by its numeric value.
+var priorityNames = [...]string{
+	PriorityEmerg:   "emerg",
+	PriorityAlert:   "alert",
+	PriorityCrit:    "crit",
+	PriorityErr:     "err",
+	PriorityWarning: "warning",
+	PriorityNotice:  "notice",
+	PriorityInfo:    "info",
+	PriorityDebug:   "debug",
+}
+
+// String returns the canonical syslog severity name for p, e.g. "crit".
+func (p Priority) String() string {
+	if int(p) < len(priorityNames) {
+		return priorityNames[p]
+	}
+	return fmt.Sprintf("priority(%d)", uint8(p))
+}
+
 type Facility uint8
 
 const (
@@ -34,10 +56,74 @@ const (
 	FacilitySyslog
 	FacilityLpr
 	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+
+	// facilities 12-15 are reserved by the syslog spec and have no
+	// canonical name, so FacilityLocal0 picks back up at 16.
+	FacilityLocal0 Facility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
 
 	FacilityUnknown // custom facility used to delimite those that we know
 )
 
+// facilityNames holds the canonical syslog facility name for each
+// Facility that has one; facilities 12-15 are reserved and unnamed.
+var facilityNames = map[Facility]string{
+	FacilityKern:     "kern",
+	FacilityUser:     "user",
+	FacilityMail:     "mail",
+	FacilityDaemon:   "daemon",
+	FacilityAuth:     "auth",
+	FacilitySyslog:   "syslog",
+	FacilityLpr:      "lpr",
+	FacilityNews:     "news",
+	FacilityUucp:     "uucp",
+	FacilityCron:     "cron",
+	FacilityAuthPriv: "authpriv",
+	FacilityFtp:      "ftp",
+	FacilityLocal0:   "local0",
+	FacilityLocal1:   "local1",
+	FacilityLocal2:   "local2",
+	FacilityLocal3:   "local3",
+	FacilityLocal4:   "local4",
+	FacilityLocal5:   "local5",
+	FacilityLocal6:   "local6",
+	FacilityLocal7:   "local7",
+}
+
+// String returns the canonical syslog facility name for f, e.g.
+// "authpriv", or a "facility(N)" placeholder for reserved values that
+// have no canonical name.
+func (f Facility) String() string {
+	if name, ok := facilityNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("facility(%d)", uint8(f))
+}
+
+// ParseFacility looks up a Facility by its canonical syslog name, as
+// returned by Facility.String, for config-driven filtering by name.
+func ParseFacility(name string) (Facility, error) {
+	for facility, facilityName := range facilityNames {
+		if facilityName == name {
+			return facility, nil
+		}
+	}
+	return FacilityUnknown, errors.Errorf("unknown facility %q", name)
+}
+
+// IsValidFacility reports whether facility is within the range of
+// facilities the syslog prefix format can encode (0-23), regardless of
+// whether that facility has a canonical name.
 func IsValidFacility(facility uint8) (isValid bool) {
 	isValid = (facility < uint8(FacilityUnknown))
 	return
@@ -47,33 +133,43 @@ type Message struct {
 	Priority       Priority
 	Facility       Facility
 	SequenceNumber int64
-	Timestamp      time.Time
-	Message        string
-	Metadata       map[string]string
+	// Clock is the raw microseconds-since-boot value the kernel
+	// attaches to the record, preserved alongside Timestamp so
+	// downstream consumers can pick between the monotonic and
+	// wall-clock representations.
+	Clock     int64
+	Timestamp time.Time
+	Message   string
+	Metadata  map[string]string
 }
 
 // DecodePrefix extracts both priority and facility from a given
 // syslog(2) encoded prefix.
 //
-//	   facility    priority
-//      .-----------.  .-----.
-//      |           |  |     |
-//	7  6  5  4  3  2  1  0    bits
+//		   facility    priority
+//	     .-----------.  .-----.
+//	     |           |  |     |
+//		7  6  5  4  3  2  1  0    bits
 //
 // ps.: the priority does not need to be verified because we're
-//      picking the first 3 bits and there's no way of having a
-//	wrong priority given that the set of possible values has
-//	8 numbers.
-func DecodePrefix(prefix uint8) (priority Priority, facility Facility) {
+//
+//	     picking the first 3 bits and there's no way of having a
+//		wrong priority given that the set of possible values has
+//		8 numbers.
+//
+// DecodePrefix returns an error when the facility bits fall outside
+// the range the syslog prefix format can encode, per IsValidFacility,
+// rather than silently coercing it to FacilityUnknown.
+func DecodePrefix(prefix uint8) (priority Priority, facility Facility, err error) {
 	const priortyMask uint8 = (1 << 3) - 1
 
 	facilityNum := prefix >> 3
 
 	if !IsValidFacility(facilityNum) {
-		facility = FacilityUnknown
-	} else {
-		facility = Facility(facilityNum)
+		err = errors.Errorf("facility %d out of range", facilityNum)
+		return
 	}
+	facility = Facility(facilityNum)
 
 	priority = Priority(prefix & priortyMask)
 
@@ -85,31 +181,49 @@ func DecodePrefix(prefix uint8) (priority Priority, facility Facility) {
 //
 // REGULAR MESSAGE:
 //
-//                  INFO		              MSG
-//     .------------------------------------------. .------.
-//    |                                            |        |
-//    |	int	int      int      char, <ignore>   | string |
-//    priority, seq, timestamp_us,flag[,..........];<message>
-//
+//	              INFO		              MSG
+//	 .------------------------------------------. .------.
+//	|                                            |        |
+//	|	int	int      int      char, <ignore>   | string |
+//	priority, seq, timestamp_us,flag[,..........];<message>
 //
 // CONTINUATION:
 //
 //	    | key | value |
 //	/x7F<THIS>=<THATTT>
 //
-func Parse(rawMsg string) (m *Message, err error) {
+// A raw record may span several lines: the first is the INFO+MSG pair
+// above, and any further lines are continuation lines belonging to it,
+// each contributing one key/value pair to Message.Metadata.
+//
+// Parse has no way of knowing the machine's boot time, so Message.Timestamp
+// ends up relative to boot (anchored at the Unix epoch) rather than
+// wall-clock. Use ParseWithBootTime when an absolute Timestamp is needed.
+func Parse(rawMsg string) (*Message, error) {
+	return ParseWithBootTime(rawMsg, time.Unix(0, 0))
+}
+
+// ParseWithBootTime parses a `kmsg` message exactly like Parse, but
+// anchors Message.Timestamp to bootTime instead of the Unix epoch,
+// turning the kernel's monotonic microseconds-since-boot clock into an
+// absolute wall-clock time. bootTime is typically read once at startup
+// from the `btime` line of /proc/stat.
+func ParseWithBootTime(rawMsg string, bootTime time.Time) (m *Message, err error) {
 	if rawMsg == "" {
 		err = errors.Errorf("msg must not be empty")
 		return
 	}
 
-	splittedMessage := strings.SplitN(rawMsg, ";", 2)
+	lines := strings.Split(rawMsg, "\n")
+
+	splittedMessage := strings.SplitN(lines[0], ";", 2)
 	if len(splittedMessage) < 2 {
 		err = errors.Errorf("message field not present")
 		return
 	}
 
 	m = new(Message)
+	m.Metadata = make(map[string]string)
 
 	infoSection := splittedMessage[0]
 	m.Message = splittedMessage[1]
@@ -120,15 +234,44 @@ func Parse(rawMsg string) (m *Message, err error) {
 		return
 	}
 
-	_, err = strconv.ParseInt(splittedInfoSection[0], 10, 8)
+	prefix, err := strconv.ParseUint(splittedInfoSection[0], 10, 8)
 	if err != nil {
 		err = errors.Wrapf(err,
 			"couldn't convert priority to int")
 		return
 	}
+	m.Priority, m.Facility, err = DecodePrefix(uint8(prefix))
+	if err != nil {
+		err = errors.Wrapf(err, "couldn't decode prefix")
+		return
+	}
 
+	m.SequenceNumber, err = strconv.ParseInt(splittedInfoSection[1], 10, 64)
+	if err != nil {
+		err = errors.Wrapf(err, "couldn't convert sequence number to int")
+		return
+	}
+
+	m.Clock, err = strconv.ParseInt(splittedInfoSection[2], 10, 64)
+	if err != nil {
+		err = errors.Wrapf(err, "couldn't convert timestamp to int")
+		return
+	}
+	m.Timestamp = bootTime.Add(time.Duration(m.Clock) * time.Microsecond)
+
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
 
-	// CC: make sure that the prefix is well-formed
+		kv := strings.SplitN(strings.TrimPrefix(line, "\x7F"), "=", 2)
+		if len(kv) < 2 {
+			err = errors.Errorf("continuation line %q missing '='", line)
+			return
+		}
+
+		m.Metadata[kv[0]] = kv[1]
+	}
 
 	return
 }
@@ -0,0 +1,140 @@
+package kmsg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFacilityString(t *testing.T) {
+	cases := []struct {
+		facility Facility
+		want     string
+	}{
+		{FacilityKern, "kern"},
+		{FacilityAuthPriv, "authpriv"},
+		{FacilityLocal3, "local3"},
+		{Facility(12), "facility(12)"},
+		{Facility(15), "facility(15)"},
+	}
+
+	for _, c := range cases {
+		if got := c.facility.String(); got != c.want {
+			t.Errorf("Facility(%d).String() = %q, want %q", uint8(c.facility), got, c.want)
+		}
+	}
+}
+
+func TestPriorityString(t *testing.T) {
+	cases := []struct {
+		priority Priority
+		want     string
+	}{
+		{PriorityEmerg, "emerg"},
+		{PriorityCrit, "crit"},
+		{PriorityDebug, "debug"},
+	}
+
+	for _, c := range cases {
+		if got := c.priority.String(); got != c.want {
+			t.Errorf("Priority(%d).String() = %q, want %q", uint8(c.priority), got, c.want)
+		}
+	}
+}
+
+func TestParseFacility(t *testing.T) {
+	facility, err := ParseFacility("authpriv")
+	if err != nil {
+		t.Fatalf("ParseFacility(\"authpriv\") returned error: %v", err)
+	}
+	if facility != FacilityAuthPriv {
+		t.Errorf("ParseFacility(\"authpriv\") = %v, want %v", facility, FacilityAuthPriv)
+	}
+
+	if _, err := ParseFacility("bogus"); err == nil {
+		t.Error("ParseFacility(\"bogus\") expected an error, got nil")
+	}
+}
+
+func TestIsValidFacility(t *testing.T) {
+	if !IsValidFacility(uint8(FacilityLocal7)) {
+		t.Errorf("IsValidFacility(%d) = false, want true", FacilityLocal7)
+	}
+	if IsValidFacility(uint8(FacilityUnknown)) {
+		t.Errorf("IsValidFacility(%d) = true, want false", FacilityUnknown)
+	}
+	if IsValidFacility(200) {
+		t.Error("IsValidFacility(200) = true, want false")
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	priority, facility, err := DecodePrefix(3<<3 | 2)
+	if err != nil {
+		t.Fatalf("DecodePrefix(26) returned error: %v", err)
+	}
+	if priority != PriorityCrit || facility != FacilityDaemon {
+		t.Errorf("DecodePrefix(26) = %v, %v, want %v, %v", priority, facility, PriorityCrit, FacilityDaemon)
+	}
+
+	if _, _, err := DecodePrefix(25 << 3); err == nil {
+		t.Error("DecodePrefix with out-of-range facility expected an error, got nil")
+	}
+}
+
+func TestParseHappyPath(t *testing.T) {
+	m, err := Parse("6,339,130261651,-;pci_raw_set_power_state: 0000:00:1c.0 set power state d0\n\x7FSUBSYSTEM=pci\n\x7FDEVICE=+pci:0000:00:1c.0")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if m.Priority != PriorityInfo || m.Facility != FacilityKern {
+		t.Errorf("Parse priority/facility = %v, %v, want %v, %v", m.Priority, m.Facility, PriorityInfo, FacilityKern)
+	}
+	if m.SequenceNumber != 339 {
+		t.Errorf("Parse SequenceNumber = %d, want 339", m.SequenceNumber)
+	}
+	if m.Clock != 130261651 {
+		t.Errorf("Parse Clock = %d, want 130261651", m.Clock)
+	}
+	if m.Message != "pci_raw_set_power_state: 0000:00:1c.0 set power state d0" {
+		t.Errorf("Parse Message = %q", m.Message)
+	}
+	if m.Metadata["SUBSYSTEM"] != "pci" || m.Metadata["DEVICE"] != "+pci:0000:00:1c.0" {
+		t.Errorf("Parse Metadata = %v", m.Metadata)
+	}
+}
+
+func TestParseWithBootTime(t *testing.T) {
+	bootTime := time.Unix(1000, 0)
+
+	m, err := ParseWithBootTime("6,339,1000000,-;hello", bootTime)
+	if err != nil {
+		t.Fatalf("ParseWithBootTime returned error: %v", err)
+	}
+
+	want := bootTime.Add(time.Second)
+	if !m.Timestamp.Equal(want) {
+		t.Errorf("ParseWithBootTime Timestamp = %v, want %v", m.Timestamp, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"missing message field", "6,339,1000,-"},
+		{"info section too short", "6,339;hello"},
+		{"continuation line missing equals", "6,339,1000,-;hello\n\x7FSUBSYSTEM"},
+		{"out of range facility", "200,339,1000,-;hello"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Parse(c.raw); err == nil {
+				t.Errorf("Parse(%q) expected an error, got nil", c.raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package kmsg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// MaxLineLength caps how much text a single /dev/kmsg record may carry
+// before the writer installed by SetupLogger splits it into another
+// record, matching the kernel's printk buffer limit (1024 bytes) minus
+// room for the <PRI> prefix and other bookkeeping the kernel adds.
+const MaxLineLength = 1024 - 48
+
+// LoggerOption configures the writer installed by SetupLogger.
+type LoggerOption func(*kmsgWriter)
+
+// WithFacility sets the syslog facility SetupLogger tags each line
+// with. The default is FacilityUser.
+func WithFacility(facility Facility) LoggerOption {
+	return func(w *kmsgWriter) {
+		w.facility = facility
+	}
+}
+
+// WithPriority sets the syslog priority SetupLogger tags each line
+// with. The default is PriorityInfo.
+func WithPriority(priority Priority) LoggerOption {
+	return func(w *kmsgWriter) {
+		w.priority = priority
+	}
+}
+
+// kmsgWriter is the io.Writer SetupLogger installs on a *log.Logger. It
+// formats every write as one or more `<PRI>prefix: message` records and
+// sends them to /dev/kmsg.
+type kmsgWriter struct {
+	file   *os.File
+	extra  io.Writer
+	prefix string
+
+	facility Facility
+	priority Priority
+}
+
+// SetupLogger installs an io.Writer on l that sends each log line to
+// /dev/kmsg, formatted as "<PRI>prefix: message" where PRI encodes
+// facility*8+priority (FacilityUser/PriorityInfo by default, override
+// with WithFacility/WithPriority). Lines longer than MaxLineLength are
+// split across multiple records rather than truncated. When extra is
+// non-nil, every write is also teed to it, which is useful for
+// mirroring to stderr during development. SetupLogger returns a
+// wrapped error if the process lacks CAP_SYS_ADMIN, so callers can fall
+// back to a regular logger.
+func SetupLogger(l *log.Logger, prefix string, extra io.Writer, opts ...LoggerOption) error {
+	file, err := os.OpenFile(devKmsg, os.O_WRONLY, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return errors.Wrapf(err, "writing to %s requires CAP_SYS_ADMIN", devKmsg)
+		}
+		return errors.Wrapf(err, "couldn't open %s for writing", devKmsg)
+	}
+
+	w := &kmsgWriter{
+		file:     file,
+		extra:    extra,
+		prefix:   prefix,
+		facility: FacilityUser,
+		priority: PriorityInfo,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	l.SetOutput(w)
+	return nil
+}
+
+// Write implements io.Writer, chunking p into MaxLineLength-sized
+// records so no single /dev/kmsg write exceeds what printk will accept.
+func (w *kmsgWriter) Write(p []byte) (int, error) {
+	if w.extra != nil {
+		if _, err := w.extra.Write(p); err != nil {
+			return 0, errors.Wrap(err, "couldn't tee log line")
+		}
+	}
+
+	pri := int(w.facility)*8 + int(w.priority)
+	written := 0
+
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > MaxLineLength {
+			chunk = chunk[:MaxLineLength]
+		}
+		p = p[len(chunk):]
+
+		record := fmt.Sprintf("<%d>%s: %s", pri, w.prefix, bytes.TrimRight(chunk, "\n"))
+		if _, err := w.file.Write([]byte(record)); err != nil {
+			return written, errors.Wrapf(err, "couldn't write to %s", devKmsg)
+		}
+
+		written += len(chunk)
+	}
+
+	return written, nil
+}
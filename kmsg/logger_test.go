@@ -0,0 +1,97 @@
+package kmsg
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newTestWriter(t *testing.T, extra *bytes.Buffer, opts ...LoggerOption) (*kmsgWriter, *os.File) {
+	t.Helper()
+
+	file, err := os.CreateTemp(t.TempDir(), "kmsg")
+	if err != nil {
+		t.Fatalf("couldn't create temp file: %v", err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	w := &kmsgWriter{
+		file:     file,
+		prefix:   "myapp",
+		facility: FacilityUser,
+		priority: PriorityInfo,
+	}
+	if extra != nil {
+		w.extra = extra
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w, file
+}
+
+func readRecords(t *testing.T, file *os.File) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatalf("couldn't read back %s: %v", file.Name(), err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "<")[1:]
+}
+
+func TestKmsgWriterSplitsOversizeLines(t *testing.T) {
+	w, file := newTestWriter(t, nil)
+
+	line := strings.Repeat("x", MaxLineLength*2+10)
+	n, err := w.Write([]byte(line))
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if n != len(line) {
+		t.Errorf("Write returned n=%d, want %d", n, len(line))
+	}
+
+	records := readRecords(t, file)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3: %v", len(records), records)
+	}
+}
+
+func TestKmsgWriterTeesToExtra(t *testing.T) {
+	var extra bytes.Buffer
+	w, _ := newTestWriter(t, &extra)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	if extra.String() != "hello\n" {
+		t.Errorf("extra = %q, want %q", extra.String(), "hello\n")
+	}
+}
+
+func TestKmsgWriterDefaultFacilityAndPriority(t *testing.T) {
+	w, file := newTestWriter(t, nil)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	records := readRecords(t, file)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1: %v", len(records), records)
+	}
+
+	wantPri := strconv.Itoa(int(FacilityUser)*8 + int(PriorityInfo))
+	gotPri := strings.SplitN(records[0], ">", 2)[0]
+	if gotPri != wantPri {
+		t.Errorf("record PRI = %q, want %q", gotPri, wantPri)
+	}
+}
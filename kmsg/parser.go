@@ -0,0 +1,258 @@
+package kmsg
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// devKmsg is the canonical path to the kernel's structured log device.
+const devKmsg = "/dev/kmsg"
+
+// procStat is where the kernel's boot time can be read from, via its
+// `btime` line.
+const procStat = "/proc/stat"
+
+// maxRecordSize is large enough to hold a single /dev/kmsg record; the
+// kernel never emits records bigger than this (see printk.c's
+// CONSOLE_EXT_LOG_MAX).
+const maxRecordSize = 8 * 1024
+
+// pollTimeoutMillis bounds how long waitReadable blocks between checks
+// of the closed channel, so Close is never stuck waiting on poll(2).
+const pollTimeoutMillis = 250
+
+// Logger is the minimal hook a Parser uses to report malformed records
+// and non-fatal read errors it encounters while streaming, without
+// pulling in any particular logging library.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Parser streams Messages out of /dev/kmsg as the kernel produces them.
+type Parser interface {
+	// SeekEnd skips past the kernel's ring buffer history so that only
+	// messages produced after this call are delivered.
+	SeekEnd() error
+
+	// Parse spawns a goroutine that reads records from /dev/kmsg and
+	// decodes them into Messages. The returned channel is closed once
+	// the Parser is closed or the underlying device can no longer be
+	// read.
+	Parse() <-chan Message
+
+	// SetLogger installs a Logger used to report malformed records and
+	// non-fatal read errors, such as the reader falling behind the
+	// kernel ring buffer (EPIPE).
+	SetLogger(logger Logger)
+
+	// Close stops the read loop and releases the /dev/kmsg descriptor.
+	Close() error
+}
+
+// kmsgParser is the default Parser implementation, backed by /dev/kmsg.
+type kmsgParser struct {
+	file *os.File
+
+	mu     sync.Mutex
+	logger Logger
+
+	bootTime time.Time
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Option configures a Parser created by NewParser.
+type Option func(*kmsgParser) error
+
+// WithBootTime overrides the boot time the Parser anchors Message
+// timestamps to. By default NewParser reads it once from the `btime`
+// line of /proc/stat.
+func WithBootTime(bootTime time.Time) Option {
+	return func(p *kmsgParser) error {
+		p.bootTime = bootTime
+		return nil
+	}
+}
+
+// NewParser opens /dev/kmsg for non-blocking reads and returns a Parser
+// ready to stream decoded Messages via Parse.
+func NewParser(opts ...Option) (Parser, error) {
+	fd, err := syscall.Open(devKmsg, syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't open %s", devKmsg)
+	}
+
+	p := &kmsgParser{
+		file:   os.NewFile(uintptr(fd), devKmsg),
+		closed: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.bootTime.IsZero() {
+		bootTime, err := readBootTime()
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't determine boot time")
+		}
+		p.bootTime = bootTime
+	}
+
+	return p, nil
+}
+
+// readBootTime reads the `btime` line of /proc/stat, the number of
+// seconds since the Unix epoch at which the system booted.
+func readBootTime() (time.Time, error) {
+	data, err := os.ReadFile(procStat)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "couldn't read %s", procStat)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+
+		secs, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "couldn't parse btime in %s", procStat)
+		}
+
+		return time.Unix(secs, 0), nil
+	}
+
+	return time.Time{}, errors.Errorf("btime not found in %s", procStat)
+}
+
+// SeekEnd skips past the kernel's ring buffer history by seeking with
+// SEEK_DATA, as documented at
+// https://www.kernel.org/doc/Documentation/ABI/testing/dev-kmsg.
+func (p *kmsgParser) SeekEnd() error {
+	_, err := syscall.Seek(int(p.file.Fd()), 0, unix.SEEK_DATA)
+	if err != nil {
+		return errors.Wrap(err, "couldn't seek to end of /dev/kmsg")
+	}
+	return nil
+}
+
+func (p *kmsgParser) SetLogger(logger Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logger = logger
+}
+
+func (p *kmsgParser) logf(format string, v ...interface{}) {
+	p.mu.Lock()
+	logger := p.logger
+	p.mu.Unlock()
+
+	if logger != nil {
+		logger.Printf(format, v...)
+	}
+}
+
+// Parse reads fixed-size records from /dev/kmsg, one message per read,
+// and emits the decoded Messages on the returned channel until the
+// Parser is closed.
+func (p *kmsgParser) Parse() <-chan Message {
+	out := make(chan Message)
+
+	// Captured here, before Close can race with it: p.file.Fd() touches
+	// the *os.File's internal state, which Close mutates concurrently.
+	fd := int(p.file.Fd())
+
+	go func() {
+		defer close(out)
+
+		buf := make([]byte, maxRecordSize)
+
+		for {
+			select {
+			case <-p.closed:
+				return
+			default:
+			}
+
+			n, err := syscall.Read(fd, buf)
+			switch {
+			case err == syscall.EAGAIN:
+				if err := p.waitReadable(fd); err != nil {
+					if err != errParserClosed {
+						p.logf("kmsg: poll on %s failed: %v", devKmsg, err)
+					}
+					return
+				}
+				continue
+			case err == syscall.EPIPE:
+				p.logf("kmsg: reader fell behind, some messages were overwritten")
+				continue
+			case err != nil:
+				p.logf("kmsg: read from %s failed: %v", devKmsg, err)
+				return
+			}
+
+			m, err := ParseWithBootTime(string(buf[:n]), p.bootTime)
+			if err != nil {
+				p.logf("kmsg: malformed record: %v", err)
+				continue
+			}
+
+			select {
+			case out <- *m:
+			case <-p.closed:
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+var errParserClosed = errors.New("kmsg: parser closed")
+
+// waitReadable blocks until fd has data available to read, the parser
+// is closed (returning errParserClosed), or poll(2) fails.
+func (p *kmsgParser) waitReadable(fd int) error {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+
+	for {
+		select {
+		case <-p.closed:
+			return errParserClosed
+		default:
+		}
+
+		n, err := unix.Poll(fds, pollTimeoutMillis)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return errors.Wrap(err, "poll failed")
+		}
+		if n > 0 {
+			return nil
+		}
+	}
+}
+
+// Close stops the read loop and releases the /dev/kmsg descriptor. It
+// is safe to call Close more than once.
+func (p *kmsgParser) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	return p.file.Close()
+}
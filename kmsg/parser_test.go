@@ -0,0 +1,101 @@
+package kmsg
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newPipeParser returns a kmsgParser backed by the read end of a pipe
+// instead of /dev/kmsg, letting the read loop and waitReadable be
+// exercised without real kmsg/root access. The write end is returned so
+// tests can feed records (or simulate /dev/kmsg going silent).
+//
+// Like NewParser, the read end is made non-blocking as a raw descriptor
+// and then wrapped with os.NewFile; an *os.File from os.Pipe would be put
+// back into blocking mode by the Fd call in Parse.
+func newPipeParser(t *testing.T) (*kmsgParser, *os.File) {
+	t.Helper()
+
+	fds := make([]int, 2)
+	if err := syscall.Pipe2(fds, syscall.O_CLOEXEC); err != nil {
+		t.Fatalf("couldn't create pipe: %v", err)
+	}
+
+	if err := syscall.SetNonblock(fds[0], true); err != nil {
+		t.Fatalf("couldn't set pipe non-blocking: %v", err)
+	}
+
+	r := os.NewFile(uintptr(fds[0]), "pipe-r")
+	w := os.NewFile(uintptr(fds[1]), "pipe-w")
+	t.Cleanup(func() { w.Close() })
+	t.Cleanup(func() { r.Close() })
+
+	return &kmsgParser{
+		file:     r,
+		closed:   make(chan struct{}),
+		bootTime: time.Unix(0, 0),
+	}, w
+}
+
+func TestParserEmitsMessageAfterEAGAIN(t *testing.T) {
+	p, w := newPipeParser(t)
+	defer p.Close()
+
+	out := p.Parse()
+
+	// The pipe starts empty, so the read loop must see EAGAIN and block
+	// in waitReadable until this write makes it readable.
+	if _, err := w.Write([]byte("6,339,130261651,-;hello\n")); err != nil {
+		t.Fatalf("couldn't write to pipe: %v", err)
+	}
+
+	select {
+	case m := <-out:
+		if m.Message != "hello" {
+			t.Errorf("got Message %q, want %q", m.Message, "hello")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestParserCloseUnblocksReadLoop(t *testing.T) {
+	p, _ := newPipeParser(t)
+
+	out := p.Parse()
+
+	// The pipe stays empty, so the read loop must be sitting in
+	// waitReadable; Close should unblock it within pollTimeoutMillis.
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("expected out to be closed, got a message")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for out to close after Close")
+	}
+}
+
+func TestWaitReadableUnblocksOnClose(t *testing.T) {
+	p, _ := newPipeParser(t)
+
+	done := make(chan error, 1)
+	go func() { done <- p.waitReadable(int(p.file.Fd())) }()
+
+	close(p.closed)
+
+	select {
+	case err := <-done:
+		if err != errParserClosed {
+			t.Errorf("waitReadable returned %v, want %v", err, errParserClosed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for waitReadable to unblock")
+	}
+}